@@ -0,0 +1,228 @@
+//   Copyright 2020 Vimeo
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package retry
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Backoff computes the delay to wait between retry attempts.
+type Backoff interface {
+	// Next returns the duration to wait before the next attempt, advancing
+	// any internal state.
+	Next() time.Duration
+
+	// Reset returns the Backoff to its initial state.
+	Reset()
+
+	// Clone returns an independent copy of the Backoff, safe for use by a
+	// concurrent invocation of Retry.
+	Clone() Backoff
+}
+
+// DefaultBackoff returns the Backoff used by NewRetryable when none is
+// specified: exponential backoff starting at 100ms, doubling on every
+// attempt, capped at 30s.
+func DefaultBackoff() Backoff {
+	return NewExponentialBackoff(100*time.Millisecond, 30*time.Second)
+}
+
+// ExponentialBackoff doubles its delay on every call to Next, up to Max.
+type ExponentialBackoff struct {
+	Base time.Duration
+	Max  time.Duration
+
+	attempt int
+}
+
+// NewExponentialBackoff returns an ExponentialBackoff starting at base and
+// capped at max.
+func NewExponentialBackoff(base, max time.Duration) *ExponentialBackoff {
+	return &ExponentialBackoff{Base: base, Max: max}
+}
+
+func (e *ExponentialBackoff) Next() time.Duration {
+	d := e.Base
+	for i := 0; i < e.attempt && d < e.Max; i++ {
+		d *= 2
+	}
+	if d > e.Max {
+		d = e.Max
+	}
+	e.attempt++
+	return d
+}
+
+func (e *ExponentialBackoff) Reset() { e.attempt = 0 }
+
+func (e *ExponentialBackoff) Clone() Backoff {
+	return &ExponentialBackoff{Base: e.Base, Max: e.Max}
+}
+
+// ConstantBackoff returns the same delay on every call to Next.
+type ConstantBackoff struct {
+	Delay time.Duration
+}
+
+// NewConstantBackoff returns a ConstantBackoff that always waits delay.
+func NewConstantBackoff(delay time.Duration) *ConstantBackoff {
+	return &ConstantBackoff{Delay: delay}
+}
+
+func (c *ConstantBackoff) Next() time.Duration { return c.Delay }
+func (c *ConstantBackoff) Reset()              {}
+func (c *ConstantBackoff) Clone() Backoff      { return &ConstantBackoff{Delay: c.Delay} }
+
+// LinearBackoff increases its delay by Step on every call to Next, up to
+// Max.
+type LinearBackoff struct {
+	Step time.Duration
+	Max  time.Duration
+
+	attempt int
+}
+
+// NewLinearBackoff returns a LinearBackoff that increases by step on every
+// attempt, capped at max.
+func NewLinearBackoff(step, max time.Duration) *LinearBackoff {
+	return &LinearBackoff{Step: step, Max: max}
+}
+
+func (l *LinearBackoff) Next() time.Duration {
+	l.attempt++
+	d := l.Step * time.Duration(l.attempt)
+	if d > l.Max {
+		d = l.Max
+	}
+	return d
+}
+
+func (l *LinearBackoff) Reset() { l.attempt = 0 }
+
+func (l *LinearBackoff) Clone() Backoff {
+	return &LinearBackoff{Step: l.Step, Max: l.Max}
+}
+
+// ExponentialFullJitter implements the "full jitter" scheme described in
+// https://aws.amazon.com/blogs/architecture/timeouts-retries-and-backoff-with-jitter/:
+// sleep = random(0, min(cap, base*2^attempt)). It avoids the thundering-herd
+// effect of plain exponential backoff, where every caller wakes up and
+// retries at the same instant.
+type ExponentialFullJitter struct {
+	Base time.Duration
+	Cap  time.Duration
+
+	mu      sync.Mutex
+	rnd     *rand.Rand
+	attempt int
+}
+
+// NewExponentialFullJitter returns an ExponentialFullJitter with the given
+// base delay and cap.
+func NewExponentialFullJitter(base, cap time.Duration) *ExponentialFullJitter {
+	return &ExponentialFullJitter{Base: base, Cap: cap, rnd: newRand()}
+}
+
+func (e *ExponentialFullJitter) Next() time.Duration {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	ceil := e.Base
+	for i := 0; i < e.attempt && ceil < e.Cap; i++ {
+		ceil *= 2
+	}
+	if ceil > e.Cap {
+		ceil = e.Cap
+	}
+	e.attempt++
+	if ceil <= 0 {
+		return 0
+	}
+	return time.Duration(e.rnd.Int63n(int64(ceil) + 1))
+}
+
+func (e *ExponentialFullJitter) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.attempt = 0
+}
+
+// Clone returns an independent ExponentialFullJitter with a freshly-seeded
+// RNG, so concurrent Retryable invocations don't share randomness.
+func (e *ExponentialFullJitter) Clone() Backoff {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return &ExponentialFullJitter{Base: e.Base, Cap: e.Cap, rnd: newRand()}
+}
+
+// DecorrelatedJitter implements the "decorrelated jitter" scheme from the
+// same AWS article: sleep_n = min(cap, random(base, sleep_{n-1}*3)). The
+// previous sleep is seeded to Base on Reset.
+type DecorrelatedJitter struct {
+	Base time.Duration
+	Cap  time.Duration
+
+	mu   sync.Mutex
+	rnd  *rand.Rand
+	prev time.Duration
+}
+
+// NewDecorrelatedJitter returns a DecorrelatedJitter with the given base
+// delay and cap.
+func NewDecorrelatedJitter(base, cap time.Duration) *DecorrelatedJitter {
+	return &DecorrelatedJitter{Base: base, Cap: cap, rnd: newRand(), prev: base}
+}
+
+func (d *DecorrelatedJitter) Next() time.Duration {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	upper := d.prev * 3
+	if upper <= d.Base {
+		upper = d.Base + 1
+	}
+	next := d.Base + time.Duration(d.rnd.Int63n(int64(upper-d.Base)))
+	if next > d.Cap {
+		next = d.Cap
+	}
+	d.prev = next
+	return next
+}
+
+func (d *DecorrelatedJitter) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.prev = d.Base
+}
+
+// Clone returns an independent DecorrelatedJitter with a freshly-seeded
+// RNG, so concurrent Retryable invocations don't share randomness.
+func (d *DecorrelatedJitter) Clone() Backoff {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return &DecorrelatedJitter{Base: d.Base, Cap: d.Cap, rnd: newRand(), prev: d.Base}
+}
+
+// randSeedCounter is mixed into newRand's seed so Backoffs created in quick
+// succession (e.g. via Clone in a tight loop) don't end up with the same
+// time.Now().UnixNano() seed.
+var randSeedCounter int64
+
+// newRand returns a *rand.Rand seeded independently of any other call.
+func newRand() *rand.Rand {
+	seed := time.Now().UnixNano() + atomic.AddInt64(&randSeedCounter, 1)
+	return rand.New(rand.NewSource(seed))
+}
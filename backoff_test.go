@@ -0,0 +1,95 @@
+//   Copyright 2020 Vimeo
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package retry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExponentialBackoffDoublesAndCaps(t *testing.T) {
+	b := NewExponentialBackoff(10*time.Millisecond, 35*time.Millisecond)
+	want := []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 35 * time.Millisecond, 35 * time.Millisecond}
+	for i, w := range want {
+		if got := b.Next(); got != w {
+			t.Fatalf("Next() #%d = %s, want %s", i, got, w)
+		}
+	}
+	b.Reset()
+	if got := b.Next(); got != 10*time.Millisecond {
+		t.Fatalf("Next() after Reset = %s, want 10ms", got)
+	}
+}
+
+func TestConstantBackoffNeverChanges(t *testing.T) {
+	b := NewConstantBackoff(5 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		if got := b.Next(); got != 5*time.Millisecond {
+			t.Fatalf("Next() #%d = %s, want 5ms", i, got)
+		}
+	}
+}
+
+func TestLinearBackoffIncreasesAndCaps(t *testing.T) {
+	b := NewLinearBackoff(10*time.Millisecond, 25*time.Millisecond)
+	want := []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 25 * time.Millisecond}
+	for i, w := range want {
+		if got := b.Next(); got != w {
+			t.Fatalf("Next() #%d = %s, want %s", i, got, w)
+		}
+	}
+}
+
+func TestExponentialFullJitterStaysWithinCeiling(t *testing.T) {
+	b := NewExponentialFullJitter(10*time.Millisecond, 100*time.Millisecond)
+	ceilings := []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 80 * time.Millisecond, 100 * time.Millisecond}
+	for i, ceil := range ceilings {
+		got := b.Next()
+		if got < 0 || got > ceil {
+			t.Fatalf("Next() #%d = %s, want in [0, %s]", i, got, ceil)
+		}
+	}
+}
+
+func TestDecorrelatedJitterStaysWithinBounds(t *testing.T) {
+	b := NewDecorrelatedJitter(10*time.Millisecond, 50*time.Millisecond)
+	for i := 0; i < 10; i++ {
+		got := b.Next()
+		if got < 10*time.Millisecond || got > 50*time.Millisecond {
+			t.Fatalf("Next() #%d = %s, want in [10ms, 50ms]", i, got)
+		}
+	}
+}
+
+func TestBackoffCloneIsIndependent(t *testing.T) {
+	b := NewExponentialBackoff(10*time.Millisecond, time.Second)
+	b.Next()
+	b.Next()
+	clone := b.Clone()
+	if got := clone.Next(); got != 10*time.Millisecond {
+		t.Fatalf("Clone().Next() = %s, want 10ms (fresh state)", got)
+	}
+	if got := b.Next(); got != 40*time.Millisecond {
+		t.Fatalf("original.Next() = %s, want 40ms (unaffected by clone)", got)
+	}
+}
+
+func TestJitterBackoffCloneUsesIndependentRNG(t *testing.T) {
+	b := NewExponentialFullJitter(10*time.Millisecond, time.Second)
+	clone := b.Clone().(*ExponentialFullJitter)
+	if clone.rnd == b.rnd {
+		t.Fatal("Clone() shares the same *rand.Rand as the original")
+	}
+}
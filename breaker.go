@@ -0,0 +1,145 @@
+//   Copyright 2020 Vimeo
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package retry
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Breaker gates attempts so a Retryable fails fast once a downstream has
+// been failing for long enough, instead of burning through MaxSteps on
+// every call.
+type Breaker interface {
+	// Allow reports whether an attempt should proceed.
+	Allow() bool
+	// RecordSuccess reports a successful attempt.
+	RecordSuccess()
+	// RecordFailure reports a failed attempt.
+	RecordFailure()
+}
+
+// BreakerOpenError is returned by Retryable.Retry when Breaker.Allow
+// refuses an attempt.
+type BreakerOpenError struct {
+	// Attempt is the 0-indexed attempt number at which the breaker
+	// refused.
+	Attempt int32
+}
+
+func (e *BreakerOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker open, refusing attempt %d", e.Attempt)
+}
+
+// RollingWindowBreaker is a Breaker that opens once the failure rate over a
+// trailing window of recent outcomes reaches Threshold, and stays open for
+// OpenDuration before letting a single trial attempt through.
+type RollingWindowBreaker struct {
+	// WindowSize is the number of recent outcomes tracked.
+	WindowSize int
+	// Threshold is the failure ratio, in (0, 1], at or above which the
+	// breaker opens.
+	Threshold float64
+	// OpenDuration is how long the breaker stays open before allowing a
+	// trial attempt.
+	OpenDuration time.Duration
+
+	mu            sync.Mutex
+	outcomes      []bool // true = success
+	openedAt      time.Time
+	isOpen        bool
+	probeInFlight bool // a single trial attempt has been let through and not yet recorded
+}
+
+// NewRollingWindowBreaker returns a RollingWindowBreaker that opens once the
+// failure rate over the last windowSize outcomes reaches threshold, staying
+// open for openDuration.
+func NewRollingWindowBreaker(windowSize int, threshold float64, openDuration time.Duration) *RollingWindowBreaker {
+	return &RollingWindowBreaker{
+		WindowSize:   windowSize,
+		Threshold:    threshold,
+		OpenDuration: openDuration,
+	}
+}
+
+// Allow reports whether an attempt should proceed, letting a single trial
+// attempt through once OpenDuration has elapsed since the breaker opened. A
+// second, concurrent caller is refused until that trial attempt is recorded
+// via RecordSuccess or RecordFailure, so the breaker can't be flooded the
+// instant OpenDuration elapses.
+func (b *RollingWindowBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.isOpen {
+		return true
+	}
+	if b.probeInFlight {
+		return false
+	}
+	if time.Since(b.openedAt) >= b.OpenDuration {
+		b.probeInFlight = true
+		return true
+	}
+	return false
+}
+
+func (b *RollingWindowBreaker) RecordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.record(true)
+}
+
+func (b *RollingWindowBreaker) RecordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.record(false)
+}
+
+// record appends an outcome and re-evaluates whether the breaker should
+// open. Callers must hold b.mu.
+func (b *RollingWindowBreaker) record(success bool) {
+	wasProbe := b.probeInFlight
+	b.probeInFlight = false
+	// A trial attempt decides the breaker's state by itself: success closes
+	// it, failure sends it back to sleep for another OpenDuration, without
+	// waiting for the window to refill.
+	if wasProbe {
+		if success {
+			b.isOpen = false
+		} else {
+			b.openedAt = time.Now()
+		}
+		return
+	}
+
+	b.outcomes = append(b.outcomes, success)
+	if len(b.outcomes) > b.WindowSize {
+		b.outcomes = b.outcomes[len(b.outcomes)-b.WindowSize:]
+	}
+	if len(b.outcomes) < b.WindowSize {
+		return
+	}
+	failures := 0
+	for _, ok := range b.outcomes {
+		if !ok {
+			failures++
+		}
+	}
+	if float64(failures)/float64(len(b.outcomes)) >= b.Threshold {
+		b.isOpen = true
+		b.openedAt = time.Now()
+	}
+}
@@ -0,0 +1,94 @@
+//   Copyright 2020 Vimeo
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package retry
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRollingWindowBreakerOpensAtThreshold(t *testing.T) {
+	b := NewRollingWindowBreaker(4, 0.5, time.Hour)
+	for _, ok := range []bool{true, true, false, false} {
+		if ok {
+			b.RecordSuccess()
+		} else {
+			b.RecordFailure()
+		}
+	}
+	if b.Allow() {
+		t.Fatal("Allow() = true, want false once failure ratio reaches Threshold")
+	}
+}
+
+func TestRollingWindowBreakerAllowsSingleProbeAfterOpenDuration(t *testing.T) {
+	b := NewRollingWindowBreaker(2, 0.5, 10*time.Millisecond)
+	b.RecordFailure()
+	b.RecordFailure()
+	if b.Allow() {
+		t.Fatal("Allow() = true immediately after opening, want false")
+	}
+	time.Sleep(15 * time.Millisecond)
+
+	var wg sync.WaitGroup
+	allowed := make([]bool, 20)
+	for i := range allowed {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			allowed[i] = b.Allow()
+		}(i)
+	}
+	wg.Wait()
+
+	count := 0
+	for _, ok := range allowed {
+		if ok {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Fatalf("%d of 20 concurrent callers let through, want exactly 1 trial attempt", count)
+	}
+}
+
+func TestRollingWindowBreakerProbeSuccessCloses(t *testing.T) {
+	b := NewRollingWindowBreaker(2, 0.5, time.Millisecond)
+	b.RecordFailure()
+	b.RecordFailure()
+	time.Sleep(2 * time.Millisecond)
+	if !b.Allow() {
+		t.Fatal("Allow() = false, want true for the trial attempt")
+	}
+	b.RecordSuccess()
+	if !b.Allow() {
+		t.Fatal("Allow() = false after a successful probe, want the breaker to be closed")
+	}
+}
+
+func TestRollingWindowBreakerProbeFailureReopens(t *testing.T) {
+	b := NewRollingWindowBreaker(2, 0.5, time.Millisecond)
+	b.RecordFailure()
+	b.RecordFailure()
+	time.Sleep(2 * time.Millisecond)
+	if !b.Allow() {
+		t.Fatal("Allow() = false, want true for the trial attempt")
+	}
+	b.RecordFailure()
+	if b.Allow() {
+		t.Fatal("Allow() = true immediately after a failed probe, want the breaker to stay open")
+	}
+}
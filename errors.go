@@ -0,0 +1,91 @@
+//   Copyright 2020 Vimeo
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package retry
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// RetryableError is implemented by errors that want to explicitly opt into
+// or out of retrying, overriding the default classification in
+// Retryable.Retry. Permanent and WithRetryable construct errors satisfying
+// this interface.
+type RetryableError interface {
+	Retryable() bool
+}
+
+// permanentError marks an error as non-retryable.
+type permanentError struct {
+	err error
+}
+
+func (p *permanentError) Error() string   { return p.err.Error() }
+func (p *permanentError) Unwrap() error   { return p.err }
+func (p *permanentError) Retryable() bool { return false }
+
+// Permanent wraps err so that Retryable.Retry's default ShouldRetry treats
+// it as terminal, even though it would otherwise be retried. It returns nil
+// if err is nil.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// retryableError marks an error as retryable, or not, per an explicit flag.
+type retryableError struct {
+	err       error
+	retryable bool
+}
+
+func (r *retryableError) Error() string   { return r.err.Error() }
+func (r *retryableError) Unwrap() error   { return r.err }
+func (r *retryableError) Retryable() bool { return r.retryable }
+
+// WithRetryable wraps err with an explicit retryability flag, so a caller
+// deep in a call stack can signal whether Retryable.Retry should continue
+// without the top-level caller having to configure a custom ShouldRetry. It
+// returns nil if err is nil.
+func WithRetryable(err error, retryable bool) error {
+	if err == nil {
+		return nil
+	}
+	return &retryableError{err: err, retryable: retryable}
+}
+
+// DelayOverride is implemented by errors that want to suggest the delay
+// before the next attempt themselves, overriding the value Retryable.Retry
+// would otherwise compute from B. httpretry uses this to honor a server's
+// Retry-After header instead of the configured backoff.
+type DelayOverride interface {
+	RetryDelay() time.Duration
+}
+
+// defaultShouldRetry is used by Retryable.Retry when ShouldRetry is nil. It
+// treats context cancellation and deadline errors as terminal, and
+// otherwise unwraps err via errors.As to honor RetryableError.
+func defaultShouldRetry(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var re RetryableError
+	if errors.As(err, &re) {
+		return re.Retryable()
+	}
+	return true
+}
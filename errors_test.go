@@ -0,0 +1,66 @@
+//   Copyright 2020 Vimeo
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestDefaultShouldRetryTreatsContextErrorsAsTerminal(t *testing.T) {
+	if defaultShouldRetry(context.Canceled) {
+		t.Error("defaultShouldRetry(context.Canceled) = true, want false")
+	}
+	if defaultShouldRetry(context.DeadlineExceeded) {
+		t.Error("defaultShouldRetry(context.DeadlineExceeded) = true, want false")
+	}
+}
+
+func TestDefaultShouldRetryDefaultsToTrue(t *testing.T) {
+	if !defaultShouldRetry(errors.New("boom")) {
+		t.Error("defaultShouldRetry(plain error) = false, want true")
+	}
+}
+
+func TestPermanentIsNotRetried(t *testing.T) {
+	err := Permanent(errors.New("boom"))
+	if defaultShouldRetry(err) {
+		t.Error("defaultShouldRetry(Permanent(err)) = true, want false")
+	}
+	if Permanent(nil) != nil {
+		t.Error("Permanent(nil) != nil")
+	}
+}
+
+func TestWithRetryableHonorsExplicitFlag(t *testing.T) {
+	if defaultShouldRetry(WithRetryable(errors.New("boom"), false)) {
+		t.Error("defaultShouldRetry(WithRetryable(err, false)) = true, want false")
+	}
+	if !defaultShouldRetry(WithRetryable(errors.New("boom"), true)) {
+		t.Error("defaultShouldRetry(WithRetryable(err, true)) = false, want true")
+	}
+	if WithRetryable(nil, true) != nil {
+		t.Error("WithRetryable(nil, true) != nil")
+	}
+}
+
+func TestRetryableErrorUnwraps(t *testing.T) {
+	sentinel := errors.New("sentinel")
+	err := Permanent(sentinel)
+	if !errors.Is(err, sentinel) {
+		t.Error("errors.Is(Permanent(sentinel), sentinel) = false, want true")
+	}
+}
@@ -0,0 +1,171 @@
+//   Copyright 2020 Vimeo
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package httpretry adapts retry.Retryable to operations that perform a
+// single HTTP round-trip, classifying the response by status code and
+// honoring any Retry-After header the server returns.
+package httpretry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/silviutroscot/go-retry"
+)
+
+// defaultMaxRetryAfter bounds how long a server-supplied Retry-After header
+// is honored, so a misbehaving server can't stall a caller indefinitely.
+const defaultMaxRetryAfter = 5 * time.Minute
+
+// HTTPRetryable wraps retry.Retryable for operations that return an
+// *http.Response.
+type HTTPRetryable struct {
+	// R is the underlying retryable; its Backoff, MaxSteps and ShouldRetry
+	// are used as normal for transport errors and are also the fallback
+	// backoff for retryable status codes that don't carry a Retry-After
+	// header.
+	R *retry.Retryable
+
+	// MaxRetryAfter caps the delay honored from a Retry-After header.
+	// defaultMaxRetryAfter is used if zero.
+	MaxRetryAfter time.Duration
+}
+
+// NewHTTPRetryable returns an HTTPRetryable wrapping a retry.Retryable
+// configured with maxSteps attempts and the default backoff.
+func NewHTTPRetryable(maxSteps int32) *HTTPRetryable {
+	return &HTTPRetryable{R: retry.NewRetryable(maxSteps)}
+}
+
+// Do calls f at most R.MaxSteps times, going through R.Retry so that
+// R.PerAttemptTimeout, R.MaxElapsed, R.OnRetry and R.Breaker apply to HTTP
+// calls exactly as they do to any other Retryable operation. Transport
+// errors are retried according to R.ShouldRetry (or defaultShouldRetry, if
+// nil); a returned response is retried if its status is 5xx, 429, or 408,
+// and treated as final otherwise. On a retried response, Do drains and
+// closes the body itself so the underlying connection isn't leaked, and
+// honors a Retry-After header in place of R.B's computed delay. If every
+// attempt is exhausted, Do returns the *retry.RetryError from R.Retry.
+func (h *HTTPRetryable) Do(ctx context.Context, f func(context.Context) (*http.Response, error)) (*http.Response, error) {
+	maxRetryAfter := h.MaxRetryAfter
+	if maxRetryAfter <= 0 {
+		maxRetryAfter = defaultMaxRetryAfter
+	}
+
+	var result *http.Response
+	err := h.R.Retry(ctx, func(attemptCtx context.Context) error {
+		resp, err := f(attemptCtx)
+		if err != nil {
+			return err
+		}
+
+		if !classifyStatus(resp.StatusCode) {
+			result = resp
+			return nil
+		}
+
+		statusErr := fmt.Errorf("retryable HTTP status: %s", resp.Status)
+		drainAndClose(resp)
+		if d, ok := retryAfterDelay(resp); ok {
+			if d > maxRetryAfter {
+				d = maxRetryAfter
+			}
+			return &retryAfterError{err: statusErr, delay: d}
+		}
+		return statusErr
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// retryAfterError wraps a retryable-status error with the delay parsed from
+// the response's Retry-After header, so retry.Retryable.Retry honors the
+// server's requested delay instead of its own backoff.
+type retryAfterError struct {
+	err   error
+	delay time.Duration
+}
+
+func (e *retryAfterError) Error() string             { return e.err.Error() }
+func (e *retryAfterError) Unwrap() error             { return e.err }
+func (e *retryAfterError) RetryDelay() time.Duration { return e.delay }
+
+// DecodeResponse calls f via h.Do and, on success, JSON-decodes the
+// response body into a value of type T. The body is always closed before
+// returning.
+func DecodeResponse[T any](ctx context.Context, h *HTTPRetryable, f func(context.Context) (*http.Response, error)) (T, error) {
+	var out T
+	resp, err := h.Do(ctx, f)
+	if err != nil {
+		return out, err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return out, fmt.Errorf("failed to decode response body: %w", err)
+	}
+	return out, nil
+}
+
+// classifyStatus reports whether a response with the given status code
+// should be retried: 5xx, 429 (Too Many Requests) and 408 (Request
+// Timeout) are retryable; all other codes, including the rest of 4xx, are
+// treated as final.
+func classifyStatus(code int) bool {
+	switch {
+	case code == http.StatusTooManyRequests, code == http.StatusRequestTimeout:
+		return true
+	case code >= 500:
+		return true
+	default:
+		return false
+	}
+}
+
+// retryAfterDelay parses resp's Retry-After header, supporting both the
+// delta-seconds and HTTP-date forms from RFC 7231 section 7.1.3.
+func retryAfterDelay(resp *http.Response) (time.Duration, bool) {
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// drainAndClose reads resp.Body to completion and closes it so the
+// connection backing a retried response can be reused instead of leaked.
+func drainAndClose(resp *http.Response) {
+	if resp == nil || resp.Body == nil {
+		return
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+}
@@ -0,0 +1,144 @@
+//   Copyright 2020 Vimeo
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package httpretry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	retry "github.com/silviutroscot/go-retry"
+)
+
+func newResp(status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Status:     http.StatusText(status),
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Header:     make(http.Header),
+	}
+}
+
+func TestDoReturnsFirstNonRetryableResponse(t *testing.T) {
+	h := NewHTTPRetryable(5)
+	h.R.B = retry.NewConstantBackoff(time.Millisecond)
+	calls := 0
+	resp, err := h.Do(context.Background(), func(context.Context) (*http.Response, error) {
+		calls++
+		return newResp(http.StatusNotFound, ""), nil
+	})
+	if err != nil {
+		t.Fatalf("Do returned %v, want nil", err)
+	}
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("StatusCode = %d, want 404", resp.StatusCode)
+	}
+	if calls != 1 {
+		t.Fatalf("calls = %d, want 1 (404 must not be retried)", calls)
+	}
+}
+
+func TestDoRetriesRetryableStatusThenSucceeds(t *testing.T) {
+	h := NewHTTPRetryable(5)
+	h.R.B = retry.NewConstantBackoff(time.Millisecond)
+	calls := 0
+	resp, err := h.Do(context.Background(), func(context.Context) (*http.Response, error) {
+		calls++
+		if calls < 3 {
+			return newResp(http.StatusServiceUnavailable, ""), nil
+		}
+		return newResp(http.StatusOK, ""), nil
+	})
+	if err != nil {
+		t.Fatalf("Do returned %v, want nil", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("StatusCode = %d, want 200", resp.StatusCode)
+	}
+	if calls != 3 {
+		t.Fatalf("calls = %d, want 3", calls)
+	}
+}
+
+func TestDoHonorsRetryAfterHeader(t *testing.T) {
+	h := NewHTTPRetryable(2)
+	// A long default backoff that would make the test slow if Retry-After
+	// weren't honored in its place.
+	h.R.B = retry.NewConstantBackoff(time.Hour)
+	calls := 0
+	start := time.Now()
+	_, err := h.Do(context.Background(), func(context.Context) (*http.Response, error) {
+		calls++
+		resp := newResp(http.StatusTooManyRequests, "")
+		resp.Header.Set("Retry-After", "0")
+		return resp, nil
+	})
+	elapsed := time.Since(start)
+	var re *retry.RetryError
+	if !errors.As(err, &re) {
+		t.Fatalf("Do returned %T, want *retry.RetryError", err)
+	}
+	if elapsed > time.Second {
+		t.Fatalf("Do took %s, Retry-After: 0 should have bypassed the hour-long backoff", elapsed)
+	}
+	if calls != 2 {
+		t.Fatalf("calls = %d, want 2", calls)
+	}
+}
+
+func TestDoRetriesTransportErrors(t *testing.T) {
+	h := NewHTTPRetryable(3)
+	h.R.B = retry.NewConstantBackoff(time.Millisecond)
+	calls := 0
+	_, err := h.Do(context.Background(), func(context.Context) (*http.Response, error) {
+		calls++
+		return nil, errors.New("connection reset")
+	})
+	var re *retry.RetryError
+	if !errors.As(err, &re) {
+		t.Fatalf("Do returned %T, want *retry.RetryError", err)
+	}
+	if calls != 3 {
+		t.Fatalf("calls = %d, want 3", calls)
+	}
+}
+
+func TestDoUsesLiveServerForIntegrationSanity(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	h := NewHTTPRetryable(1)
+	resp, err := h.Do(context.Background(), func(ctx context.Context) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+		if err != nil {
+			return nil, err
+		}
+		return http.DefaultClient.Do(req)
+	})
+	if err != nil {
+		t.Fatalf("Do returned %v, want nil", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("StatusCode = %d, want 200", resp.StatusCode)
+	}
+}
@@ -16,6 +16,7 @@ package retry
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 )
@@ -27,11 +28,39 @@ type Retryable struct {
 
 	// ShouldRetry is a filter function to indicate whether to continue
 	// iterating based on the error.
-	// An implementation that uniformly returns true is used if nil
+	// If nil, defaultShouldRetry is used: it honors the RetryableError
+	// interface (via errors.As) and treats context.Canceled and
+	// context.DeadlineExceeded as terminal.
 	ShouldRetry func(error) bool
 
 	// Maximum retry attempts
 	MaxSteps int32
+
+	// PerAttemptTimeout, if positive, bounds each invocation of `f` with its
+	// own derived context, so a single stuck attempt can't consume the
+	// whole retry budget. An attempt that fails only because this deadline
+	// fired is always retried, regardless of ShouldRetry: only the outer
+	// ctx expiring is treated as terminal.
+	PerAttemptTimeout time.Duration
+
+	// MaxElapsed, if positive, bounds the total wall-clock time spent
+	// retrying, including time spent sleeping between attempts. It is
+	// checked before each attempt; once it is exceeded, Retry returns a
+	// *RetryError holding the errors seen so far (LastErr gives the last).
+	MaxElapsed time.Duration
+
+	// OnRetry, if non-nil, is called before each sleep that follows a
+	// retryable failure, with the attempt number (0-indexed), the error that
+	// triggered the retry, and the delay about to be slept. The final failed
+	// attempt is also followed by a call and a sleep, so OnRetry runs
+	// MaxSteps times when every attempt fails. Useful for logging, metrics,
+	// or tracing integration.
+	OnRetry func(attempt int32, err error, nextDelay time.Duration)
+
+	// Breaker, if non-nil, is consulted before each attempt; if it refuses
+	// the attempt, Retry returns a *BreakerOpenError immediately instead of
+	// sleeping through the rest of MaxSteps.
+	Breaker Breaker
 }
 
 // NewRetryable returns a newly constructed Retryable instance
@@ -44,28 +73,68 @@ func NewRetryable(MaxSteps int32) *Retryable {
 }
 
 // Retry calls the function `f` at most `MaxSteps` times using the exponential
-// backoff parameters defined in `B`, or until the context expires.
+// backoff parameters defined in `B`, or until the context expires, subject to
+// PerAttemptTimeout and MaxElapsed if set.
 func (r *Retryable) Retry(ctx context.Context, f func(context.Context) error) error {
 	b := r.B.Clone()
 	b.Reset()
 	filter := r.ShouldRetry
 	if filter == nil {
-		filter = func(err error) bool {
-			return true
-		}
+		filter = defaultShouldRetry
 	}
-	errors := make([]error, 0, 0)
+	start := time.Now()
+	attemptErrs := make([]error, 0, 0)
 	for n := int32(0); n < r.MaxSteps; n++ {
-		err := f(ctx)
+		if r.MaxElapsed > 0 && time.Since(start) > r.MaxElapsed {
+			return &RetryError{attemptErrs: attemptErrs, elapsed: time.Since(start)}
+		}
+		if r.Breaker != nil && !r.Breaker.Allow() {
+			return &BreakerOpenError{Attempt: n}
+		}
+
+		attemptCtx := ctx
+		var cancel context.CancelFunc
+		if r.PerAttemptTimeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, r.PerAttemptTimeout)
+		}
+		err := f(attemptCtx)
+		// If only the per-attempt deadline fired, err is (or wraps)
+		// attemptCtx.Err(), i.e. context.DeadlineExceeded, while ctx itself
+		// is still live. Without this, defaultShouldRetry (and most custom
+		// filters) would see the same sentinel that context cancellation of
+		// ctx produces and treat a single slow attempt as terminal,
+		// defeating the point of PerAttemptTimeout. Tying this to the
+		// actual err (rather than just attemptCtx.Err()) keeps it from
+		// overriding a terminal error f returns for its own reasons, such
+		// as Permanent or WithRetryable(err, false), after ignoring
+		// attemptCtx and running past the deadline anyway.
+		attemptTimedOut := cancel != nil && ctx.Err() == nil && attemptCtx.Err() != nil && errors.Is(err, attemptCtx.Err())
+		if cancel != nil {
+			cancel()
+		}
 		if err == nil {
+			if r.Breaker != nil {
+				r.Breaker.RecordSuccess()
+			}
 			return nil
 		}
-		if !filter(err) {
+		if r.Breaker != nil {
+			r.Breaker.RecordFailure()
+		}
+		if !attemptTimedOut && !filter(err) {
 			return err
 		}
-		errors = append(errors, err)
+		attemptErrs = append(attemptErrs, err)
+		nextDelay := b.Next()
+		var delayOverride DelayOverride
+		if errors.As(err, &delayOverride) {
+			nextDelay = delayOverride.RetryDelay()
+		}
+		if r.OnRetry != nil {
+			r.OnRetry(n, err, nextDelay)
+		}
 		select {
-		case <-time.After(b.Next()):
+		case <-time.After(nextDelay):
 			continue
 		case <-ctx.Done():
 			return fmt.Errorf(
@@ -73,7 +142,7 @@ func (r *Retryable) Retry(ctx context.Context, f func(context.Context) error) er
 				ctx.Err(), n)
 		}
 	}
-	return fmt.Errorf("aborting retry. errors: %+v", errors)
+	return &RetryError{attemptErrs: attemptErrs, elapsed: time.Since(start)}
 }
 
 // Retry calls the function `f` at most `steps` times using the exponential
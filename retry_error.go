@@ -0,0 +1,55 @@
+//   Copyright 2020 Vimeo
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package retry
+
+import (
+	"fmt"
+	"time"
+)
+
+// RetryError is returned by Retryable.Retry when all attempts are
+// exhausted without success, or when MaxElapsed is exceeded before the
+// next attempt. It implements Unwrap() []error so
+// errors.Is/errors.As can match against any individual attempt's error.
+type RetryError struct {
+	attemptErrs []error
+	elapsed     time.Duration
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf(
+		"aborting retry after %d attempts, elapsed %s: %+v",
+		len(e.attemptErrs), e.elapsed, e.attemptErrs)
+}
+
+// Unwrap exposes every per-attempt error, per the Go 1.20 multi-error
+// convention, so errors.Is/errors.As can match against any of them.
+func (e *RetryError) Unwrap() []error { return e.attemptErrs }
+
+// Attempts returns the number of attempts made before giving up.
+func (e *RetryError) Attempts() int { return len(e.attemptErrs) }
+
+// LastErr returns the error from the final attempt, or nil if there were no
+// attempts.
+func (e *RetryError) LastErr() error {
+	if len(e.attemptErrs) == 0 {
+		return nil
+	}
+	return e.attemptErrs[len(e.attemptErrs)-1]
+}
+
+// Elapsed returns the total wall-clock time spent across all attempts and
+// the sleeps between them.
+func (e *RetryError) Elapsed() time.Duration { return e.elapsed }
@@ -0,0 +1,79 @@
+//   Copyright 2020 Vimeo
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryErrorUnwrapsEachAttempt(t *testing.T) {
+	sentinel := errors.New("sentinel")
+	r := &Retryable{B: NewConstantBackoff(time.Millisecond), MaxSteps: 3}
+	err := r.Retry(context.Background(), func(context.Context) error {
+		return sentinel
+	})
+	var re *RetryError
+	if !errors.As(err, &re) {
+		t.Fatalf("Retry returned %T, want *RetryError", err)
+	}
+	if !errors.Is(re, sentinel) {
+		t.Error("errors.Is(re, sentinel) = false, want true")
+	}
+	if re.Attempts() != 3 {
+		t.Errorf("Attempts() = %d, want 3", re.Attempts())
+	}
+	if re.LastErr() != sentinel {
+		t.Errorf("LastErr() = %v, want sentinel", re.LastErr())
+	}
+	if re.Elapsed() <= 0 {
+		t.Error("Elapsed() <= 0, want positive")
+	}
+}
+
+func TestRetryErrorLastErrOnNoAttempts(t *testing.T) {
+	re := &RetryError{}
+	if re.LastErr() != nil {
+		t.Errorf("LastErr() = %v, want nil", re.LastErr())
+	}
+	if re.Attempts() != 0 {
+		t.Errorf("Attempts() = %d, want 0", re.Attempts())
+	}
+}
+
+func TestOnRetryReceivesTriggeringError(t *testing.T) {
+	sentinel := errors.New("sentinel")
+	var seen []error
+	r := &Retryable{
+		B:        NewConstantBackoff(time.Millisecond),
+		MaxSteps: 2,
+		OnRetry: func(attempt int32, err error, nextDelay time.Duration) {
+			seen = append(seen, err)
+		},
+	}
+	_ = r.Retry(context.Background(), func(context.Context) error {
+		return sentinel
+	})
+	if len(seen) != 2 {
+		t.Fatalf("OnRetry saw %d errors, want 2", len(seen))
+	}
+	for _, err := range seen {
+		if err != sentinel {
+			t.Errorf("OnRetry saw %v, want sentinel", err)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+//   Copyright 2020 Vimeo
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetrySucceedsAfterFailures(t *testing.T) {
+	r := &Retryable{B: NewConstantBackoff(time.Millisecond), MaxSteps: 5}
+	attempts := 0
+	err := r.Retry(context.Background(), func(context.Context) error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("transient")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Retry returned %v, want nil", err)
+	}
+	if attempts != 3 {
+		t.Fatalf("attempts = %d, want 3", attempts)
+	}
+}
+
+func TestRetryExhaustsMaxSteps(t *testing.T) {
+	r := &Retryable{B: NewConstantBackoff(time.Millisecond), MaxSteps: 3}
+	attempts := 0
+	err := r.Retry(context.Background(), func(context.Context) error {
+		attempts++
+		return errors.New("always fails")
+	})
+	var re *RetryError
+	if !errors.As(err, &re) {
+		t.Fatalf("Retry returned %T, want *RetryError", err)
+	}
+	if re.Attempts() != 3 {
+		t.Fatalf("Attempts() = %d, want 3", re.Attempts())
+	}
+	if attempts != 3 {
+		t.Fatalf("attempts = %d, want 3", attempts)
+	}
+}
+
+func TestRetryPerAttemptTimeoutDoesNotAbortDefaultShouldRetry(t *testing.T) {
+	// Regression test: a single attempt that only exceeds PerAttemptTimeout
+	// must be retried by defaultShouldRetry, not treated as the terminal
+	// context.DeadlineExceeded case reserved for the outer ctx expiring.
+	r := &Retryable{
+		B:                 NewConstantBackoff(time.Millisecond),
+		MaxSteps:          3,
+		PerAttemptTimeout: 10 * time.Millisecond,
+	}
+	attempts := 0
+	err := r.Retry(context.Background(), func(attemptCtx context.Context) error {
+		attempts++
+		<-attemptCtx.Done()
+		return attemptCtx.Err()
+	})
+	var re *RetryError
+	if !errors.As(err, &re) {
+		t.Fatalf("Retry returned %v (%T), want *RetryError after exhausting MaxSteps", err, err)
+	}
+	if attempts != 3 {
+		t.Fatalf("attempts = %d, want 3 (all attempts should run despite PerAttemptTimeout)", attempts)
+	}
+}
+
+func TestRetryPerAttemptTimeoutDoesNotOverridePermanent(t *testing.T) {
+	// Regression test: if f ignores attemptCtx and returns its own
+	// terminal error after running past PerAttemptTimeout, that error must
+	// still abort the retry loop immediately, not be force-retried just
+	// because attemptCtx happened to expire too.
+	sentinel := errors.New("sentinel")
+	r := &Retryable{
+		B:                 NewConstantBackoff(time.Millisecond),
+		MaxSteps:          3,
+		PerAttemptTimeout: 10 * time.Millisecond,
+	}
+	attempts := 0
+	err := r.Retry(context.Background(), func(attemptCtx context.Context) error {
+		attempts++
+		time.Sleep(15 * time.Millisecond)
+		return Permanent(sentinel)
+	})
+	if !errors.Is(err, sentinel) {
+		t.Fatalf("Retry returned %v, want an error wrapping sentinel", err)
+	}
+	if attempts != 1 {
+		t.Fatalf("attempts = %d, want 1 (Permanent must abort immediately)", attempts)
+	}
+}
+
+func TestRetryOuterContextExpiryIsTerminal(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := &Retryable{B: NewConstantBackoff(time.Millisecond), MaxSteps: 5}
+	attempts := 0
+	err := r.Retry(ctx, func(context.Context) error {
+		attempts++
+		return ctx.Err()
+	})
+	if err == nil {
+		t.Fatal("Retry returned nil, want an error for an already-cancelled ctx")
+	}
+	if attempts != 1 {
+		t.Fatalf("attempts = %d, want 1 (outer ctx expiry must not be retried)", attempts)
+	}
+}
+
+func TestRetryMaxElapsedShortCircuits(t *testing.T) {
+	r := &Retryable{
+		B:          NewConstantBackoff(5 * time.Millisecond),
+		MaxSteps:   1000,
+		MaxElapsed: 20 * time.Millisecond,
+	}
+	err := r.Retry(context.Background(), func(context.Context) error {
+		return errors.New("always fails")
+	})
+	var re *RetryError
+	if !errors.As(err, &re) {
+		t.Fatalf("Retry returned %T, want *RetryError", err)
+	}
+}
+
+func TestRetryOnRetryCalledBeforeEachSleep(t *testing.T) {
+	r := &Retryable{B: NewConstantBackoff(time.Millisecond), MaxSteps: 3}
+	var calls []int32
+	r.OnRetry = func(attempt int32, err error, nextDelay time.Duration) {
+		calls = append(calls, attempt)
+	}
+	_ = r.Retry(context.Background(), func(context.Context) error {
+		return errors.New("always fails")
+	})
+	if len(calls) != 3 {
+		t.Fatalf("OnRetry called %d times, want 3", len(calls))
+	}
+	for i, a := range calls {
+		if a != int32(i) {
+			t.Fatalf("calls[%d] = %d, want %d", i, a, i)
+		}
+	}
+}
+
+func TestRetryBreakerOpenFailsFast(t *testing.T) {
+	r := &Retryable{
+		B:        NewConstantBackoff(time.Millisecond),
+		MaxSteps: 5,
+		Breaker:  &alwaysOpenBreaker{},
+	}
+	attempts := 0
+	err := r.Retry(context.Background(), func(context.Context) error {
+		attempts++
+		return nil
+	})
+	var boe *BreakerOpenError
+	if !errors.As(err, &boe) {
+		t.Fatalf("Retry returned %T, want *BreakerOpenError", err)
+	}
+	if attempts != 0 {
+		t.Fatalf("attempts = %d, want 0", attempts)
+	}
+}
+
+type alwaysOpenBreaker struct{}
+
+func (*alwaysOpenBreaker) Allow() bool    { return false }
+func (*alwaysOpenBreaker) RecordSuccess() {}
+func (*alwaysOpenBreaker) RecordFailure() {}